@@ -0,0 +1,263 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/kingpin"
+)
+
+var (
+	noCacheFlag    = kingpin.Flag("no-cache", "Disable the on-disk result cache.").Bool()
+	cacheCleanFlag = kingpin.Flag("cache-clean", "Remove all cached linter results and exit.").Bool()
+)
+
+// cacheEntry is what gets gob-encoded to disk for one (linter, paths) run.
+type cacheEntry struct {
+	Issues  []cachedIssue
+	Elapsed time.Duration
+}
+
+// cachedIssue is a gob-friendly copy of Issue; Issue itself isn't encoded
+// directly so its fields can change shape without breaking old caches.
+type cachedIssue struct {
+	Linter   string
+	Severity string
+	Path     string
+	Line     int
+	Col      int
+	Message  string
+}
+
+// cacheDir returns $XDG_CACHE_HOME/gometalinter, falling back to
+// ~/.cache/gometalinter.
+func cacheDir() string {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "gometalinter")
+	}
+	return filepath.Join(os.Getenv("HOME"), ".cache", "gometalinter")
+}
+
+func cachePath(key string) string {
+	return filepath.Join(cacheDir(), "cache", key[:2], key)
+}
+
+func cleanCache() error {
+	return os.RemoveAll(filepath.Join(cacheDir(), "cache"))
+}
+
+// hashLinterInput computes the cache key for a linter run: the linter
+// binary's mtime and size, the command used to invoke it, and the
+// concatenated hashes of every .go file reachable from paths.
+func hashLinterInput(name, command, paths string) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "command:%s\n", command)
+
+	if fields := strings.Fields(command); len(fields) > 0 {
+		if resolved, err := exec.LookPath(fields[0]); err == nil {
+			if info, err := os.Stat(resolved); err == nil {
+				fmt.Fprintf(h, "bin:%s:%d:%d\n", resolved, info.ModTime().UnixNano(), info.Size())
+			}
+		}
+	}
+
+	files, err := goFilesUnder(paths)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		sum := sha256.Sum256(data)
+		fmt.Fprintf(h, "file:%s:%s\n", file, hex.EncodeToString(sum[:]))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// goFilesUnder returns every .go file in each of the (already
+// package-expanded, whitespace-separated) directories in paths, plus
+// every .go file in the packages they transitively import, so that
+// editing a dependency invalidates the cache even when the package
+// itself is untouched. GOROOT packages are not followed: the standard
+// library doesn't change under a given toolchain, and following it would
+// pull the whole runtime tree into every hash.
+func goFilesUnder(paths string) ([]string, error) {
+	visited := map[string]bool{}
+	var files []string
+
+	var visit func(dir string) error
+	visit = func(dir string) error {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		if visited[abs] {
+			return nil
+		}
+		visited[abs] = true
+
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") {
+				files = append(files, filepath.Join(dir, entry.Name()))
+			}
+		}
+
+		pkg, err := build.ImportDir(dir, 0)
+		if err != nil {
+			// No buildable package here (e.g. only _test.go files): no
+			// imports to follow, but the .go files above still count.
+			return nil
+		}
+		for _, imp := range pkg.Imports {
+			resolvedDir, goroot, err := resolveImportDir(imp, dir)
+			if err != nil {
+				// Loud, not debug-only: a cache key that silently drops an
+				// import serves stale results forever for that dependency.
+				warn("cache: could not resolve import %q from %s, cache may go stale for it: %s", imp, dir, err)
+				continue
+			}
+			if goroot {
+				continue
+			}
+			if err := visit(resolvedDir); err != nil {
+				debug("cache: could not hash import %q (%s): %s", imp, resolvedDir, err)
+			}
+		}
+		return nil
+	}
+
+	for _, dir := range strings.Fields(paths) {
+		if err := visit(dir); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// resolveImportDir finds the source directory for imp as seen from dir,
+// and reports whether it's part of the standard library. It tries
+// GOPATH-style resolution first, since that's cheap and handles the
+// common case; go/build doesn't understand modules, so if that fails
+// (and the failure isn't simply "this is GOROOT-relative"), it falls
+// back to asking the go tool itself via "go list", which does.
+func resolveImportDir(imp, dir string) (resolvedDir string, goroot bool, err error) {
+	if pkg, buildErr := build.Import(imp, dir, build.FindOnly); buildErr == nil {
+		return pkg.Dir, pkg.Goroot, nil
+	}
+
+	cmd := exec.Command("go", "list", "-f", "{{.Dir}}|{{.Goroot}}", imp)
+	cmd.Dir = dir
+	out, listErr := cmd.CombinedOutput()
+	if listErr != nil {
+		return "", false, listErr
+	}
+	parts := strings.SplitN(strings.TrimSpace(string(out)), "|", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", false, fmt.Errorf("unexpected output from go list %s: %q", imp, out)
+	}
+	return parts[0], parts[1] == "true", nil
+}
+
+// loadCachedIssues returns a previous run's Issues for (name, command,
+// paths), if the cache has a matching, still-valid entry.
+func loadCachedIssues(name, command, paths string) ([]*Issue, time.Duration, bool) {
+	if *noCacheFlag {
+		return nil, 0, false
+	}
+	key, err := hashLinterInput(name, command, paths)
+	if err != nil {
+		debug("cache: could not hash input for %s: %s", name, err)
+		return nil, 0, false
+	}
+	f, err := os.Open(cachePath(key))
+	if err != nil {
+		return nil, 0, false
+	}
+	defer f.Close()
+
+	var entry cacheEntry
+	if err := gob.NewDecoder(f).Decode(&entry); err != nil {
+		debug("cache: corrupt entry for %s: %s", name, err)
+		return nil, 0, false
+	}
+	issues := make([]*Issue, 0, len(entry.Issues))
+	for _, ci := range entry.Issues {
+		issues = append(issues, &Issue{
+			linter:   ci.Linter,
+			severity: Severity(ci.Severity),
+			path:     ci.Path,
+			line:     ci.Line,
+			col:      ci.Col,
+			message:  ci.Message,
+		})
+	}
+	debug("cache: hit for %s (%d issues)", name, len(issues))
+	return issues, entry.Elapsed, true
+}
+
+// storeCachedIssues persists issues for (name, command, paths), using a
+// temp-file-then-rename so a crash mid-write never leaves a corrupt entry.
+func storeCachedIssues(name, command, paths string, issues []*Issue, elapsed time.Duration) {
+	if *noCacheFlag {
+		return
+	}
+	key, err := hashLinterInput(name, command, paths)
+	if err != nil {
+		debug("cache: could not hash input for %s: %s", name, err)
+		return
+	}
+
+	entry := cacheEntry{Elapsed: elapsed}
+	for _, issue := range issues {
+		entry.Issues = append(entry.Issues, cachedIssue{
+			Linter:   issue.linter,
+			Severity: string(issue.severity),
+			Path:     issue.path,
+			Line:     issue.line,
+			Col:      issue.col,
+			Message:  issue.message,
+		})
+	}
+
+	path := cachePath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		debug("cache: could not create cache dir: %s", err)
+		return
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "tmp-")
+	if err != nil {
+		debug("cache: could not create temp file: %s", err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(entry); err != nil {
+		tmp.Close()
+		debug("cache: could not encode entry: %s", err)
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		debug("cache: could not close temp file: %s", err)
+		return
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		debug("cache: could not rename temp file into place: %s", err)
+	}
+}
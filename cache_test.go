@@ -0,0 +1,78 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// writeModule lays out a minimal module with a dependency package on disk
+// and returns its root.
+func writeModule(t *testing.T, pkgSrc, depSrc string) string {
+	t.Helper()
+	root, err := ioutil.TempDir("", "gometalinter-cache-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(root) })
+
+	mod := "module example.com/widget\n\ngo 1.16\n"
+	if err := ioutil.WriteFile(filepath.Join(root, "go.mod"), []byte(mod), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pkgDir := filepath.Join(root, "pkg")
+	depDir := filepath.Join(root, "dep")
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(depDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(pkgDir, "pkg.go"), []byte(pkgSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(depDir, "dep.go"), []byte(depSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestHashLinterInputInvalidatesOnDependencyChange(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go tool not available")
+	}
+
+	pkgSrc := `package pkg
+
+import "example.com/widget/dep"
+
+func Use() int { return dep.Value }
+`
+	depSrcV1 := "package dep\n\nconst Value = 1\n"
+	depSrcV2 := "package dep\n\nconst Value = 2\n"
+
+	root := writeModule(t, pkgSrc, depSrcV1)
+	pkgDir := filepath.Join(root, "pkg")
+	depFile := filepath.Join(root, "dep", "dep.go")
+
+	before, err := hashLinterInput("vet", "go vet", pkgDir)
+	if err != nil {
+		t.Fatalf("hashLinterInput: %v", err)
+	}
+
+	if err := ioutil.WriteFile(depFile, []byte(depSrcV2), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := hashLinterInput("vet", "go vet", pkgDir)
+	if err != nil {
+		t.Fatalf("hashLinterInput: %v", err)
+	}
+
+	if before == after {
+		t.Fatal("hash did not change after editing a module-resolved dependency; cache would serve stale results")
+	}
+}
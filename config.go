@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/alecthomas/kingpin"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// linterSettings holds the per-linter extras that only make sense in a
+// config file: extra arguments appended to the linter's command, and a
+// deadline override consumed by --linter-deadline (see linterDeadline in
+// main.go).
+type linterSettings struct {
+	Args     []string `yaml:"args" json:"args"`
+	Deadline string   `yaml:"deadline" json:"deadline"`
+}
+
+// config is the shape of .gometalinter.yml / .gometalinter.json. Every
+// field mirrors a command-line flag of the same purpose; a flag given on
+// the command line always wins over the value loaded from the file.
+type config struct {
+	Linters          map[string]string         `yaml:"linters" json:"linters"`
+	MessageOverrides map[string]string         `yaml:"message-overrides" json:"message-overrides"`
+	Severity         map[string]string         `yaml:"severity" json:"severity"`
+	Disable          []string                  `yaml:"disable" json:"disable"`
+	Exclude          string                    `yaml:"exclude" json:"exclude"`
+	Concurrency      int                       `yaml:"concurrency" json:"concurrency"`
+	LinterSettings   map[string]linterSettings `yaml:"linter-settings" json:"linter-settings"`
+}
+
+// per-linter Args/Deadline. linterArgsFlag is filled only by applyConfig
+// from a config file and read by executeLinter; linterDeadlineFlag is
+// filled by --linter-deadline and by applyConfig, and read by
+// linterDeadline.
+var (
+	linterArgsFlag     = map[string][]string{}
+	linterDeadlineFlag = map[string]string{}
+)
+
+const (
+	configFileYAML = ".gometalinter.yml"
+	configFileJSON = ".gometalinter.json"
+)
+
+// findConfig walks up from dir looking for a .gometalinter.yml or
+// .gometalinter.json, returning the first one found.
+func findConfig(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	info, err := os.Stat(dir)
+	if err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	for {
+		for _, name := range []string{configFileYAML, configFileJSON} {
+			candidate := filepath.Join(dir, name)
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func loadConfig(path string) (*config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &config{}
+	if strings.HasSuffix(path, ".json") {
+		err = json.Unmarshal(data, cfg)
+	} else {
+		err = yaml.Unmarshal(data, cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyConfig merges cfg into the package-level flag variables, skipping
+// any field whose flag no longer holds the value it had before
+// kingpin.Parse ran (i.e. the user set it on the command line).
+func applyConfig(cfg *config, before *config) {
+	if cfg.Linters != nil && reflect.DeepEqual(lintersFlag, before.Linters) {
+		lintersFlag = cfg.Linters
+	}
+	if cfg.MessageOverrides != nil && reflect.DeepEqual(linterMessageOverrideFlag, before.MessageOverrides) {
+		linterMessageOverrideFlag = cfg.MessageOverrides
+	}
+	if cfg.Severity != nil && reflect.DeepEqual(linterSeverityFlag, before.Severity) {
+		linterSeverityFlag = cfg.Severity
+	}
+	if len(cfg.Disable) > 0 && len(*disableLintersFlag) == 0 {
+		*disableLintersFlag = cfg.Disable
+	}
+	if cfg.Exclude != "" && *excludeFlag == "" {
+		*excludeFlag = cfg.Exclude
+	}
+	if cfg.Concurrency > 0 && *concurrencyFlag == defaultConcurrency {
+		*concurrencyFlag = cfg.Concurrency
+	}
+	for name, settings := range cfg.LinterSettings {
+		if len(settings.Args) > 0 {
+			linterArgsFlag[name] = settings.Args
+		}
+		// Any entry already present came from --linter-deadline.
+		if _, set := linterDeadlineFlag[name]; settings.Deadline != "" && !set {
+			linterDeadlineFlag[name] = settings.Deadline
+		}
+	}
+}
+
+// snapshotConfig captures the flag values that applyConfig needs to know
+// the pre-kingpin.Parse defaults of, so it can tell whether the user
+// overrode them. concurrencyFlag isn't captured here: its default is only
+// applied by kingpin.Parse itself, so applyConfig instead compares it
+// against the defaultConcurrency constant.
+func snapshotConfig() *config {
+	return &config{
+		Linters:          copyStringMap(lintersFlag),
+		MessageOverrides: copyStringMap(linterMessageOverrideFlag),
+		Severity:         copyStringMap(linterSeverityFlag),
+	}
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// loadAndApplyConfig auto-discovers (or uses the --config-provided)
+// config file, and merges it over the flags that kingpin just parsed.
+func loadAndApplyConfig(before *config) {
+	path := *configFlag
+	if path == "" {
+		path = findConfig(*pathArg)
+	}
+	if path == "" {
+		return
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		kingpin.Fatalf("failed to load config %s: %s", path, err)
+	}
+	debug("loaded config from %s", path)
+	applyConfig(cfg, before)
+}
@@ -0,0 +1,130 @@
+package main
+
+import "testing"
+
+// applyConfig precedence tests.
+//
+// Known limitation: applyConfig tells "flag untouched" from "flag explicitly
+// set" by comparing the current flag value against its pre-Parse snapshot
+// (or, for concurrencyFlag, against defaultConcurrency). An explicit CLI
+// value that happens to equal that default/snapshot is indistinguishable
+// from "the user didn't pass this flag," so a config file value can still
+// win in that one case. The tests below cover the normal case, where the
+// CLI value differs from the default.
+
+func withConfigFlags(t *testing.T) {
+	savedLinters := lintersFlag
+	savedOverrides := linterMessageOverrideFlag
+	savedSeverity := linterSeverityFlag
+	savedDisable := *disableLintersFlag
+	savedExclude := *excludeFlag
+	savedConcurrency := *concurrencyFlag
+	savedArgs := linterArgsFlag
+	savedDeadlines := linterDeadlineFlag
+
+	t.Cleanup(func() {
+		lintersFlag = savedLinters
+		linterMessageOverrideFlag = savedOverrides
+		linterSeverityFlag = savedSeverity
+		*disableLintersFlag = savedDisable
+		*excludeFlag = savedExclude
+		*concurrencyFlag = savedConcurrency
+		linterArgsFlag = savedArgs
+		linterDeadlineFlag = savedDeadlines
+	})
+}
+
+func TestApplyConfigCLIOverridesFile(t *testing.T) {
+	withConfigFlags(t)
+
+	before := snapshotConfig()
+
+	// Simulate flags the user explicitly passed on the command line,
+	// each different from what's in "before" or, for Concurrency, from
+	// defaultConcurrency.
+	lintersFlag = map[string]string{"golint": "golint {path}:PATH:LINE:COL:MESSAGE"}
+	linterMessageOverrideFlag = map[string]string{"errcheck": "custom"}
+	linterSeverityFlag = map[string]string{"golint": "error"}
+	*disableLintersFlag = []string{"gotype"}
+	*excludeFlag = "cli-exclude-pattern"
+	*concurrencyFlag = defaultConcurrency + 4
+
+	cfg := &config{
+		Linters:          map[string]string{"vet": "go vet {path}:PATH:LINE:MESSAGE"},
+		MessageOverrides: map[string]string{"errcheck": "from-config"},
+		Severity:         map[string]string{"golint": "warning"},
+		Disable:          []string{"structcheck"},
+		Exclude:          "config-exclude-pattern",
+		Concurrency:      2,
+	}
+
+	applyConfig(cfg, before)
+
+	if _, ok := lintersFlag["vet"]; ok {
+		t.Errorf("lintersFlag was overwritten by config file despite being CLI-set: %v", lintersFlag)
+	}
+	if linterMessageOverrideFlag["errcheck"] != "custom" {
+		t.Errorf("linterMessageOverrideFlag = %v, want CLI value to survive", linterMessageOverrideFlag)
+	}
+	if linterSeverityFlag["golint"] != "error" {
+		t.Errorf("linterSeverityFlag = %v, want CLI value to survive", linterSeverityFlag)
+	}
+	if got := *disableLintersFlag; len(got) != 1 || got[0] != "gotype" {
+		t.Errorf("disableLintersFlag = %v, want CLI value [gotype] to survive", got)
+	}
+	if *excludeFlag != "cli-exclude-pattern" {
+		t.Errorf("excludeFlag = %q, want CLI value to survive", *excludeFlag)
+	}
+	if *concurrencyFlag != defaultConcurrency+4 {
+		t.Errorf("concurrencyFlag = %d, want CLI value %d to survive", *concurrencyFlag, defaultConcurrency+4)
+	}
+}
+
+func TestApplyConfigFillsUnsetFlags(t *testing.T) {
+	withConfigFlags(t)
+
+	before := snapshotConfig()
+	// Nothing set on the CLI: flags are still at their pre-Parse values,
+	// except concurrencyFlag, which kingpin.Parse sets to its default.
+	*concurrencyFlag = defaultConcurrency
+
+	cfg := &config{
+		Linters:     map[string]string{"vet": "go vet {path}:PATH:LINE:MESSAGE"},
+		Disable:     []string{"structcheck"},
+		Exclude:     "config-exclude-pattern",
+		Concurrency: 2,
+	}
+
+	applyConfig(cfg, before)
+
+	if lintersFlag["vet"] == "" {
+		t.Errorf("lintersFlag = %v, want config value to apply when unset on the CLI", lintersFlag)
+	}
+	if got := *disableLintersFlag; len(got) != 1 || got[0] != "structcheck" {
+		t.Errorf("disableLintersFlag = %v, want config value to apply when unset on the CLI", got)
+	}
+	if *excludeFlag != "config-exclude-pattern" {
+		t.Errorf("excludeFlag = %q, want config value to apply when unset on the CLI", *excludeFlag)
+	}
+	if *concurrencyFlag != 2 {
+		t.Errorf("concurrencyFlag = %d, want config value to apply when unset on the CLI", *concurrencyFlag)
+	}
+}
+
+// TestApplyConfigConcurrencyAmbiguityAtDefault pins the known limitation
+// documented above: a CLI value equal to defaultConcurrency can't be told
+// apart from "-j wasn't passed," so the config file wins. This is here so a
+// future change to the detection strategy is a deliberate choice, not an
+// accidental regression either way.
+func TestApplyConfigConcurrencyAmbiguityAtDefault(t *testing.T) {
+	withConfigFlags(t)
+
+	before := snapshotConfig()
+	*concurrencyFlag = defaultConcurrency // "explicit -j16", indistinguishable from unset
+
+	applyConfig(&config{Concurrency: 2}, before)
+
+	if *concurrencyFlag != 2 {
+		t.Fatalf("concurrencyFlag = %d, want 2 (known ambiguity: an explicit value equal to the default is always treated as unset)", *concurrencyFlag)
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// LinterRun records how long a single linter took to produce the Issues it
+// contributed to a report, for formatters that want to surface timing.
+type LinterRun struct {
+	Name    string
+	Elapsed time.Duration
+}
+
+// mergeRuns collapses multiple LinterRun entries for the same linter
+// (one per package, when a linter is invoked with {path} rather than
+// {paths}) into a single entry with their elapsed time summed.
+func mergeRuns(runs []LinterRun) []LinterRun {
+	order := make([]string, 0, len(runs))
+	byName := map[string]*LinterRun{}
+	for _, run := range runs {
+		if existing, ok := byName[run.Name]; ok {
+			existing.Elapsed += run.Elapsed
+			continue
+		}
+		copied := run
+		byName[run.Name] = &copied
+		order = append(order, run.Name)
+	}
+	merged := make([]LinterRun, 0, len(order))
+	for _, name := range order {
+		merged = append(merged, *byName[name])
+	}
+	return merged
+}
+
+// Formatter renders a sorted list of Issues, along with metadata about the
+// linters that produced them, to w.
+type Formatter interface {
+	Format(w io.Writer, issues Issues, runs []LinterRun) error
+}
+
+var formatters = map[string]Formatter{
+	"text":       textFormatter{},
+	"json":       jsonFormatter{},
+	"checkstyle": checkstyleFormatter{},
+	"junit-xml":  junitFormatter{},
+	"tab":        tabFormatter{},
+}
+
+// textFormatter renders one Issue per line, the historical default format.
+type textFormatter struct{}
+
+func (textFormatter) Format(w io.Writer, issues Issues, runs []LinterRun) error {
+	for _, issue := range issues {
+		if _, err := fmt.Fprintf(w, "%s\n", issue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tabFormatter renders issues as tab-separated values, one per line.
+type tabFormatter struct{}
+
+func (tabFormatter) Format(w io.Writer, issues Issues, runs []LinterRun) error {
+	tw := csv.NewWriter(w)
+	tw.Comma = '\t'
+	for _, issue := range issues {
+		col := ""
+		if issue.col != 0 {
+			col = fmt.Sprintf("%d", issue.col)
+		}
+		record := []string{issue.path, fmt.Sprintf("%d", issue.line), col, string(issue.severity), issue.linter, issue.message}
+		if err := tw.Write(record); err != nil {
+			return err
+		}
+	}
+	tw.Flush()
+	return tw.Error()
+}
+
+type jsonIssue struct {
+	Linter   string `json:"linter"`
+	Severity string `json:"severity"`
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Col      int    `json:"col,omitempty"`
+	Message  string `json:"message"`
+}
+
+// jsonFormatter renders issues as a JSON array, one object per Issue.
+type jsonFormatter struct{}
+
+func (jsonFormatter) Format(w io.Writer, issues Issues, runs []LinterRun) error {
+	out := make([]jsonIssue, 0, len(issues))
+	for _, issue := range issues {
+		out = append(out, jsonIssue{
+			Linter:   issue.linter,
+			Severity: string(issue.severity),
+			Path:     issue.path,
+			Line:     issue.line,
+			Col:      issue.col,
+			Message:  issue.message,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// checkstyleFormatter renders issues in the Checkstyle XML format understood
+// by Jenkins, GitLab and most other CI systems.
+type checkstyleFormatter struct{}
+
+type checkstyleRoot struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string           `xml:"name,attr"`
+	Errors []checkstyleItem `xml:"error"`
+}
+
+type checkstyleItem struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+func (checkstyleFormatter) Format(w io.Writer, issues Issues, runs []LinterRun) error {
+	root := checkstyleRoot{Version: "5.0"}
+	var current *checkstyleFile
+	for _, issue := range issues {
+		if current == nil || current.Name != issue.path {
+			root.Files = append(root.Files, checkstyleFile{Name: issue.path})
+			current = &root.Files[len(root.Files)-1]
+		}
+		current.Errors = append(current.Errors, checkstyleItem{
+			Line:     issue.line,
+			Column:   issue.col,
+			Severity: checkstyleSeverity(issue.severity),
+			Message:  issue.message,
+			Source:   "gometalinter." + issue.linter,
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(root)
+}
+
+func checkstyleSeverity(s Severity) string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// junitFormatter renders each linter run as a JUnit-XML testsuite, with one
+// failing testcase per Issue it produced and a single passing testcase for
+// an otherwise clean run.
+type junitFormatter struct{}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Time      string      `xml:"time,attr"`
+	TestCases []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name    string    `xml:"name,attr"`
+	Failure *junitMsg `xml:"failure,omitempty"`
+}
+
+type junitMsg struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func (junitFormatter) Format(w io.Writer, issues Issues, runs []LinterRun) error {
+	byLinter := map[string][]*Issue{}
+	for _, issue := range issues {
+		byLinter[issue.linter] = append(byLinter[issue.linter], issue)
+	}
+	root := junitSuites{}
+	for _, run := range runs {
+		linterIssues := byLinter[run.Name]
+		tests := len(linterIssues)
+		if tests == 0 {
+			tests = 1 // still emit the single passing testcase below
+		}
+		suite := junitSuite{
+			Name:     run.Name,
+			Tests:    tests,
+			Failures: len(linterIssues),
+			Time:     fmt.Sprintf("%.3f", run.Elapsed.Seconds()),
+		}
+		if len(linterIssues) == 0 {
+			suite.TestCases = append(suite.TestCases, junitCase{Name: run.Name})
+		}
+		for _, issue := range linterIssues {
+			suite.TestCases = append(suite.TestCases, junitCase{
+				Name: fmt.Sprintf("%s:%d", issue.path, issue.line),
+				Failure: &junitMsg{
+					Message: issue.message,
+					Text:    issue.String(),
+				},
+			})
+		}
+		root.Suites = append(root.Suites, suite)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(root)
+}
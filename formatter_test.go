@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+)
+
+func TestJunitFormatterTestCounts(t *testing.T) {
+	tests := []struct {
+		name         string
+		issues       Issues
+		wantTests    int
+		wantFailures int
+	}{
+		{
+			name:         "no issues still reports one passing testcase",
+			issues:       nil,
+			wantTests:    1,
+			wantFailures: 0,
+		},
+		{
+			name: "one issue",
+			issues: Issues{
+				{linter: "vet", path: "a.go", line: 1, message: "bad"},
+			},
+			wantTests:    1,
+			wantFailures: 1,
+		},
+		{
+			name: "tests tracks the number of issues, not a fixed 1",
+			issues: Issues{
+				{linter: "vet", path: "a.go", line: 1, message: "bad"},
+				{linter: "vet", path: "b.go", line: 2, message: "also bad"},
+			},
+			wantTests:    2,
+			wantFailures: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			runs := []LinterRun{{Name: "vet"}}
+			if err := (junitFormatter{}).Format(&buf, tt.issues, runs); err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+
+			var suites junitSuites
+			if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+				t.Fatalf("output is not valid XML: %v\n%s", err, buf.String())
+			}
+			if len(suites.Suites) != 1 {
+				t.Fatalf("got %d suites, want 1", len(suites.Suites))
+			}
+			suite := suites.Suites[0]
+			if suite.Tests != tt.wantTests {
+				t.Errorf("Tests = %d, want %d", suite.Tests, tt.wantTests)
+			}
+			if suite.Failures != tt.wantFailures {
+				t.Errorf("Failures = %d, want %d", suite.Failures, tt.wantFailures)
+			}
+			if suite.Tests < suite.Failures {
+				t.Errorf("Tests (%d) < Failures (%d): invalid JUnit XML", suite.Tests, suite.Failures)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import "fmt"
+
+// Severity of an Issue.
+type Severity string
+
+// Linter message severity levels.
+const (
+	Warning Severity = "warning"
+	Error   Severity = "error"
+)
+
+// Issue is a single message produced by a linter against a path and
+// (optionally) a line/column within it.
+type Issue struct {
+	linter   string
+	severity Severity
+	path     string
+	line     int
+	col      int
+	message  string
+}
+
+func (m *Issue) String() string {
+	col := ""
+	if m.col != 0 {
+		col = fmt.Sprintf("%d", m.col)
+	}
+	return fmt.Sprintf("%s:%d:%s:%s: %s", m.path, m.line, col, m.severity, m.message)
+}
+
+// Issues is a sortable list of Issue, ordered by path, then line, then
+// column.
+type Issues []*Issue
+
+func (m Issues) Len() int      { return len(m) }
+func (m Issues) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m Issues) Less(i, j int) bool {
+	a, b := m[i], m[j]
+	if a.path != b.path {
+		return a.path < b.path
+	}
+	if a.line != b.line {
+		return a.line < b.line
+	}
+	return a.col < b.col
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,16 +17,13 @@ import (
 	"github.com/alecthomas/kingpin"
 )
 
-type Severity string
-
-// Linter message severity levels.
-const (
-	Warning Severity = "warning"
-	Error   Severity = "error"
-)
-
 type Linter string
 
+// defaultConcurrency is the --concurrency default; kept as a constant so
+// a config file's concurrency setting can be told apart from an explicit
+// -j/--concurrency on the command line (see applyConfig).
+const defaultConcurrency = 16
+
 func (l Linter) Command() string {
 	return string(l[0:strings.Index(string(l), ":")])
 }
@@ -68,43 +67,38 @@ var (
 		"varcheck":    "go get github.com/opennota/check/cmd/varcheck",
 		"structcheck": "go get github.com/opennota/check/cmd/structcheck",
 	}
-	pathArg            = kingpin.Arg("path", "Directory to lint.").Default(".").String()
+	pathArg            = kingpin.Arg("path", "Directory to lint, or a path ending in /... to recurse into its packages.").Default(".").String()
 	fastFlag           = kingpin.Flag("fast", "Only run fast linters.").Bool()
 	installFlag        = kingpin.Flag("install", "Attempt to install all known linters.").Bool()
 	disableLintersFlag = kingpin.Flag("disable", "List of linters to disable.").PlaceHolder("LINTER").Short('D').Strings()
 	debugFlag          = kingpin.Flag("debug", "Display messages for failed linters, etc.").Short('d').Bool()
-	concurrencyFlag    = kingpin.Flag("concurrency", "Number of concurrent linters to run.").Default("16").Short('j').Int()
+	concurrencyFlag    = kingpin.Flag("concurrency", "Number of concurrent linters to run.").Default(strconv.Itoa(defaultConcurrency)).Short('j').Int()
 	excludeFlag        = kingpin.Flag("exclude", "Exclude messages matching this regular expression.").PlaceHolder("REGEXP").String()
+	outFormatFlag      = kingpin.Flag("out-format", "Output format for issues.").Default("text").Enum("text", "json", "checkstyle", "junit-xml", "tab")
+	nolintRequireFlag  = kingpin.Flag("nolint-require-explanation", "Ignore //nolint directives that have no trailing reason.").Bool()
+	ignoreFlag         = kingpin.Flag("ignore", "Ignore issues matching \"glob:linter,linter\" (linters optional).").PlaceHolder("GLOB:LINTER,LINTER").Strings()
+	configFlag         = kingpin.Flag("config", "Path to a .gometalinter.yml/.gometalinter.json config file (default: discovered by walking up from <path>).").PlaceHolder("PATH").String()
+	deadlineFlag       = kingpin.Flag("deadline", "Cancel a linter that hasn't finished within this duration.").Default("30s").Duration()
 )
 
 func init() {
 	kingpin.Flag("linter", "Specify a linter.").PlaceHolder("NAME:COMMAND:PATTERN").StringMapVar(&lintersFlag)
 	kingpin.Flag("message-overrides", "Override message from linter. {message} will be expanded to the original message.").PlaceHolder("LINTER:MESSAGE").StringMapVar(&linterMessageOverrideFlag)
 	kingpin.Flag("severity", "Map of linter severities.").PlaceHolder("LINTER:SEVERITY").StringMapVar(&linterSeverityFlag)
+	kingpin.Flag("linter-deadline", "Per-linter deadline override.").PlaceHolder("LINTER:DURATION").StringMapVar(&linterDeadlineFlag)
 }
 
-type Issue struct {
-	severity Severity
-	path     string
-	line     int
-	col      int
-	message  string
-}
-
-func (m *Issue) String() string {
-	col := ""
-	if m.col != 0 {
-		col = fmt.Sprintf("%d", m.col)
+// linterDeadline returns the deadline for linter name: its --linter-deadline
+// (or config linter-settings) override if one was given, otherwise the
+// global --deadline.
+func linterDeadline(name string) time.Duration {
+	if raw, ok := linterDeadlineFlag[name]; ok {
+		if d, err := time.ParseDuration(raw); err == nil {
+			return d
+		}
+		debug("invalid deadline %q for linter %s, using global deadline", raw, name)
 	}
-	return fmt.Sprintf("%s:%d:%s:%s: %s", m.path, m.line, col, m.severity, m.message)
-}
-
-type Issues []*Issue
-
-func (m Issues) Len() int      { return len(m) }
-func (m Issues) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
-func (m Issues) Less(i, j int) bool {
-	return m[i].path < m[j].path || m[i].line < m[j].line || m[i].col < m[j].col
+	return *deadlineFlag
 }
 
 func debug(format string, args ...interface{}) {
@@ -113,6 +107,12 @@ func debug(format string, args ...interface{}) {
 	}
 }
 
+// warn prints unconditionally, unlike debug: for conditions the user should
+// see even without --debug, such as a degraded (but non-fatal) cache key.
+func warn(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "WARNING: "+format+"\n", args...)
+}
+
 func formatLinters() string {
 	w := bytes.NewBuffer(nil)
 	for command, description := range lintersFlag {
@@ -152,7 +152,13 @@ Severity override map (default is "error"):
 
 %s
 `, formatLinters(), formatSeverity())
+	before := snapshotConfig()
 	kingpin.Parse()
+	if *cacheCleanFlag {
+		kingpin.FatalIfError(cleanCache(), "failed to clean cache")
+		return
+	}
+	loadAndApplyConfig(before)
 	var filter *regexp.Regexp
 	if *excludeFlag != "" {
 		filter = regexp.MustCompile(*excludeFlag)
@@ -185,9 +191,13 @@ Severity override map (default is "error"):
 	}
 
 	start := time.Now()
-	paths := *pathArg
+	packages, err := expandPackages(*pathArg)
+	kingpin.FatalIfError(err, "failed to resolve "+*pathArg)
+	allPackages := strings.Join(packages, " ")
+
 	concurrency := make(chan bool, *concurrencyFlag)
 	issues := make(chan *Issue, 100000)
+	runs := make(chan LinterRun, len(lintersFlag)*len(packages))
 	wg := &sync.WaitGroup{}
 	for name, description := range lintersFlag {
 		if _, ok := disable[name]; ok {
@@ -198,28 +208,56 @@ Severity override map (default is "error"):
 		command := parts[0]
 		pattern := parts[1]
 
-		wg.Add(1)
-		go func(name, command, pattern string) {
-			concurrency <- true
-			executeLinter(issues, name, command, pattern, paths)
-			<-concurrency
-			wg.Done()
-		}(name, command, pattern)
+		if strings.Contains(command, "{paths}") {
+			wg.Add(1)
+			go func(name, command, pattern string) {
+				concurrency <- true
+				runs <- executeLinter(issues, name, command, pattern, allPackages)
+				<-concurrency
+				wg.Done()
+			}(name, command, pattern)
+			continue
+		}
+
+		for _, pkg := range packages {
+			wg.Add(1)
+			go func(name, command, pattern, pkg string) {
+				concurrency <- true
+				runs <- executeLinter(issues, name, command, pattern, pkg)
+				<-concurrency
+				wg.Done()
+			}(name, command, pattern, pkg)
+		}
 	}
 
 	wg.Wait()
 	close(issues)
+	close(runs)
+
+	var allIssues Issues
 	for issue := range issues {
 		if filter != nil && filter.MatchString(issue.String()) {
 			continue
 		}
-		fmt.Printf("%s\n", issue)
+		allIssues = append(allIssues, issue)
+	}
+	allIssues = filterNolint(allIssues, *nolintRequireFlag, parseGlobIgnores(*ignoreFlag))
+	sort.Sort(allIssues)
+
+	var allRuns []LinterRun
+	for run := range runs {
+		allRuns = append(allRuns, run)
 	}
+	allRuns = mergeRuns(allRuns)
+
+	formatter := formatters[*outFormatFlag]
+	kingpin.FatalIfError(formatter.Format(os.Stdout, allIssues, allRuns), "failed to format issues")
+
 	elapsed := time.Now().Sub(start)
 	debug("total elapsed time %s", elapsed)
 }
 
-func executeLinter(issues chan *Issue, name, command, pattern, paths string) {
+func executeLinter(issues chan *Issue, name, command, pattern, paths string) LinterRun {
 	debug("linting with %s: %s", name, command)
 
 	start := time.Now()
@@ -230,25 +268,70 @@ func executeLinter(issues chan *Issue, name, command, pattern, paths string) {
 	re, err := regexp.Compile(pattern)
 	kingpin.FatalIfError(err, "invalid pattern for '"+command+"'")
 
+	if args := linterArgsFlag[name]; len(args) > 0 {
+		command = command + " " + strings.Join(args, " ")
+	}
 	command = strings.Replace(command, "{path}", paths, -1)
+	command = strings.Replace(command, "{paths}", paths, -1)
+
+	if cached, elapsed, ok := loadCachedIssues(name, command, paths); ok {
+		for _, issue := range cached {
+			issues <- issue
+		}
+		return LinterRun{Name: name, Elapsed: elapsed}
+	}
+
 	debug("executing %s", command)
+	ctx, cancel := context.WithTimeout(context.Background(), linterDeadline(name))
+	defer cancel()
+
 	arg0, arg1 := exArgs()
 	cmd := exec.Command(arg0, arg1, command)
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		if _, ok := err.(*exec.ExitError); !ok {
-			debug("warning: %s failed: %s", command, err)
-			return
+	setProcessGroup(cmd)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	if err := cmd.Start(); err != nil {
+		debug("warning: %s failed to start: %s", command, err)
+		return LinterRun{Name: name, Elapsed: time.Now().Sub(start)}
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case <-ctx.Done():
+		debug("warning: %s exceeded its deadline, killing", command)
+		if err := killProcessGroup(cmd); err != nil {
+			debug("warning: failed to kill %s: %s", command, err)
+		}
+		<-done
+		elapsed := time.Now().Sub(start)
+		issues <- &Issue{
+			linter:   name,
+			severity: Warning,
+			message:  fmt.Sprintf("linter %s timed out after %s", name, elapsed),
+		}
+		return LinterRun{Name: name, Elapsed: elapsed}
+
+	case err := <-done:
+		if err != nil {
+			if _, ok := err.(*exec.ExitError); !ok {
+				debug("warning: %s failed: %s", command, err)
+				return LinterRun{Name: name, Elapsed: time.Now().Sub(start)}
+			}
+			debug("warning: %s returned %s", command, err)
 		}
-		debug("warning: %s returned %s", command, err)
 	}
 
-	for _, line := range bytes.Split(out, []byte("\n")) {
+	var found []*Issue
+	for _, line := range bytes.Split(out.Bytes(), []byte("\n")) {
 		groups := re.FindAllSubmatch(line, -1)
 		if groups == nil {
 			continue
 		}
-		issue := &Issue{}
+		issue := &Issue{linter: name}
 		for i, name := range re.SubexpNames() {
 			part := string(groups[0][i])
 			switch name {
@@ -283,8 +366,11 @@ func executeLinter(issues chan *Issue, name, command, pattern, paths string) {
 			issue.severity = "error"
 		}
 		issues <- issue
+		found = append(found, issue)
 	}
 
 	elapsed := time.Now().Sub(start)
 	debug("%s linter took %s", name, elapsed)
+	storeCachedIssues(name, command, paths, found, elapsed)
+	return LinterRun{Name: name, Elapsed: elapsed}
 }
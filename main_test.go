@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLinterDeadline(t *testing.T) {
+	savedGlobal := *deadlineFlag
+	savedOverrides := linterDeadlineFlag
+	t.Cleanup(func() {
+		*deadlineFlag = savedGlobal
+		linterDeadlineFlag = savedOverrides
+	})
+
+	*deadlineFlag = 30 * time.Second
+	linterDeadlineFlag = map[string]string{
+		"slow":    "5s",
+		"garbled": "not-a-duration",
+	}
+
+	if got, want := linterDeadline("slow"), 5*time.Second; got != want {
+		t.Errorf("linterDeadline(\"slow\") = %s, want %s", got, want)
+	}
+	if got, want := linterDeadline("fast"), *deadlineFlag; got != want {
+		t.Errorf("linterDeadline(\"fast\") = %s, want global deadline %s", got, want)
+	}
+	if got, want := linterDeadline("garbled"), *deadlineFlag; got != want {
+		t.Errorf("linterDeadline(\"garbled\") = %s, want global deadline %s on parse failure", got, want)
+	}
+}
+
+func TestExecuteLinterKillsOnDeadline(t *testing.T) {
+	savedNoCache := *noCacheFlag
+	savedOverrides := linterDeadlineFlag
+	t.Cleanup(func() {
+		*noCacheFlag = savedNoCache
+		linterDeadlineFlag = savedOverrides
+	})
+
+	*noCacheFlag = true
+	linterDeadlineFlag = map[string]string{"sleeper": "50ms"}
+
+	issues := make(chan *Issue, 10)
+	start := time.Now()
+	run := executeLinter(issues, "sleeper", "sleep 5", "PATH:LINE:MESSAGE", ".")
+	elapsed := time.Since(start)
+
+	if elapsed > 2*time.Second {
+		t.Fatalf("executeLinter took %s, want it to return shortly after the 50ms deadline (kill didn't take effect)", elapsed)
+	}
+	if run.Name != "sleeper" {
+		t.Errorf("run.Name = %q, want %q", run.Name, "sleeper")
+	}
+
+	close(issues)
+	var found *Issue
+	for issue := range issues {
+		found = issue
+	}
+	if found == nil {
+		t.Fatal("expected a synthetic timeout Issue, got none")
+	}
+	if found.severity != Warning {
+		t.Errorf("timeout issue severity = %q, want %q", found.severity, Warning)
+	}
+	if !strings.Contains(found.message, "timed out") {
+		t.Errorf("timeout issue message = %q, want it to mention the timeout", found.message)
+	}
+}
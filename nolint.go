@@ -0,0 +1,177 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/kingpin"
+)
+
+// nolintRe matches "//nolint", "//nolint:golint,errcheck" and
+// "//nolint:all", optionally followed by a " // reason" explanation.
+// Submatch 1 is the comma-separated linter list, submatch 2 the reason.
+var nolintRe = regexp.MustCompile(`^//\s*nolint(?::([\w,]+))?\s*(//.*)?$`)
+
+// lintersIgnore is, for a single //nolint directive, the set of linters it
+// suppresses. An empty set (bare //nolint) means "all linters", as does a
+// set containing "all" (//nolint:all).
+type lintersIgnore map[string]bool
+
+func (s lintersIgnore) suppresses(linter string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	return s["all"] || s[linter]
+}
+
+// fileIgnores holds the nolint directives found in a single file: a
+// file-scope set (from //nolint comments found before the package clause)
+// and a per-line map for every other directive, keyed by its own line.
+type fileIgnores struct {
+	file  lintersIgnore
+	lines map[int]lintersIgnore
+}
+
+func (f *fileIgnores) suppresses(line int, linter string) bool {
+	if f == nil {
+		return false
+	}
+	if f.file != nil && f.file.suppresses(linter) {
+		return true
+	}
+	if ignore, ok := f.lines[line]; ok {
+		return ignore.suppresses(linter)
+	}
+	return false
+}
+
+// parseNolintDirectives parses path with go/parser in ParseComments mode
+// and collects its nolint directives. requireExplanation drops any
+// directive that has no trailing " // reason" text.
+func parseNolintDirectives(path string, requireExplanation bool) (*fileIgnores, error) {
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	ignores := &fileIgnores{lines: map[int]lintersIgnore{}}
+	for _, group := range astFile.Comments {
+		for _, comment := range group.List {
+			linters, reason, ok := parseNolintComment(comment.Text)
+			if !ok {
+				continue
+			}
+			if requireExplanation && reason == "" {
+				debug("ignoring %s: missing explanation", comment.Text)
+				continue
+			}
+			pos := fset.Position(comment.Pos())
+			if pos.Line < fset.Position(astFile.Package).Line {
+				// A directive above the package clause applies file-wide.
+				ignores.file = mergeIgnores(ignores.file, linters)
+				continue
+			}
+			ignores.lines[pos.Line] = mergeIgnores(ignores.lines[pos.Line], linters)
+		}
+	}
+	return ignores, nil
+}
+
+func parseNolintComment(text string) (linters lintersIgnore, reason string, ok bool) {
+	m := nolintRe.FindStringSubmatch(text)
+	if m == nil {
+		return nil, "", false
+	}
+	linters = lintersIgnore{}
+	if names := m[1]; names != "" {
+		for _, name := range strings.Split(names, ",") {
+			linters[name] = true
+		}
+	}
+	return linters, strings.TrimSpace(m[2]), true
+}
+
+func mergeIgnores(into lintersIgnore, from lintersIgnore) lintersIgnore {
+	if into == nil {
+		into = lintersIgnore{}
+	} else if len(into) == 0 {
+		// A bare directive was already recorded: keep suppressing everything.
+		return into
+	}
+	if len(from) == 0 {
+		// Bare directive: suppress everything regardless of what was
+		// already recorded.
+		return lintersIgnore{}
+	}
+	for name := range from {
+		into[name] = true
+	}
+	return into
+}
+
+// globIgnore is a single "glob:linter,linter" entry from --ignore.
+type globIgnore struct {
+	glob    string
+	linters lintersIgnore
+}
+
+func (g globIgnore) matches(path, linter string) bool {
+	ok, err := filepath.Match(g.glob, path)
+	kingpin.FatalIfError(err, "invalid --ignore glob '"+g.glob+"'")
+	return ok && g.linters.suppresses(linter)
+}
+
+func parseGlobIgnores(raw []string) []globIgnore {
+	var out []globIgnore
+	for _, entry := range raw {
+		parts := strings.SplitN(entry, ":", 2)
+		ignore := globIgnore{glob: parts[0], linters: lintersIgnore{}}
+		if len(parts) == 2 {
+			for _, name := range strings.Split(parts[1], ",") {
+				ignore.linters[name] = true
+			}
+		}
+		out = append(out, ignore)
+	}
+	return out
+}
+
+func matchesGlobIgnore(ignores []globIgnore, path, linter string) bool {
+	for _, ignore := range ignores {
+		if ignore.matches(path, linter) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterNolint drops issues suppressed by an inline //nolint directive or
+// by a --ignore glob, parsing each referenced file at most once.
+func filterNolint(issues Issues, requireExplanation bool, globIgnores []globIgnore) Issues {
+	cache := map[string]*fileIgnores{}
+	var kept Issues
+	for _, issue := range issues {
+		if matchesGlobIgnore(globIgnores, issue.path, issue.linter) {
+			continue
+		}
+		ignores, ok := cache[issue.path]
+		if !ok {
+			parsed, err := parseNolintDirectives(issue.path, requireExplanation)
+			if err != nil {
+				debug("could not parse %s for nolint directives: %s", issue.path, err)
+				parsed = nil
+			}
+			ignores = parsed
+			cache[issue.path] = ignores
+		}
+		if ignores.suppresses(issue.line, issue.linter) {
+			continue
+		}
+		kept = append(kept, issue)
+	}
+	return kept
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeGoFile(t *testing.T, src string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "gometalinter-nolint-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "f.go")
+	if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseNolintDirectives(t *testing.T) {
+	tests := []struct {
+		name               string
+		src                string
+		requireExplanation bool
+		line               int
+		linter             string
+		wantSuppressed     bool
+	}{
+		{
+			name: "trailing same-line directive suppresses only that line",
+			src: `package p
+
+func f() { //nolint:golint
+}
+
+func g() {
+}
+`,
+			line:           3,
+			linter:         "golint",
+			wantSuppressed: true,
+		},
+		{
+			name: "trailing same-line directive does not leak to other lines",
+			src: `package p
+
+func f() { //nolint:golint
+}
+
+func g() {
+}
+`,
+			line:           6,
+			linter:         "golint",
+			wantSuppressed: false,
+		},
+		{
+			name: "file-scope directive above the package clause suppresses everywhere",
+			src: `// nolint:errcheck
+
+package p
+
+func f() {
+}
+`,
+			line:           5,
+			linter:         "errcheck",
+			wantSuppressed: true,
+		},
+		{
+			name: "bare nolint:all suppresses every linter",
+			src: `package p
+
+func f() { //nolint:all
+}
+`,
+			line:           3,
+			linter:         "structcheck",
+			wantSuppressed: true,
+		},
+		{
+			name: "require-explanation drops a directive with no trailing reason",
+			src: `package p
+
+func f() { //nolint:golint
+}
+`,
+			requireExplanation: true,
+			line:               3,
+			linter:             "golint",
+			wantSuppressed:     false,
+		},
+		{
+			name: "require-explanation keeps a directive that has a reason",
+			src: `package p
+
+func f() { //nolint:golint // generated code
+}
+`,
+			requireExplanation: true,
+			line:               3,
+			linter:             "golint",
+			wantSuppressed:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeGoFile(t, tt.src)
+			ignores, err := parseNolintDirectives(path, tt.requireExplanation)
+			if err != nil {
+				t.Fatalf("parseNolintDirectives: %v", err)
+			}
+			if got := ignores.suppresses(tt.line, tt.linter); got != tt.wantSuppressed {
+				t.Errorf("suppresses(%d, %q) = %v, want %v", tt.line, tt.linter, got, tt.wantSuppressed)
+			}
+		})
+	}
+}
+
+func TestLintersIgnoreSuppresses(t *testing.T) {
+	tests := []struct {
+		name   string
+		ignore lintersIgnore
+		linter string
+		want   bool
+	}{
+		{"empty set means all linters", lintersIgnore{}, "golint", true},
+		{"named set matches listed linter", lintersIgnore{"golint": true}, "golint", true},
+		{"named set does not match unlisted linter", lintersIgnore{"golint": true}, "errcheck", false},
+		{"explicit all entry matches everything", lintersIgnore{"all": true}, "errcheck", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ignore.suppresses(tt.linter); got != tt.want {
+				t.Errorf("suppresses(%q) = %v, want %v", tt.linter, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"go/build"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/kingpin"
+)
+
+var skipFlag = kingpin.Flag("skip", "Skip directories with this name when expanding a /... path.").PlaceHolder("DIR").Strings()
+
+// defaultSkipDirs are always excluded when expanding a "/..." path, on top
+// of anything the user passes via --skip.
+var defaultSkipDirs = []string{"vendor", "testdata"}
+
+// expandPackages resolves pathArg into the list of package directories to
+// lint. A plain directory is returned as-is; a path ending in "/..."
+// (or the bare "...") is walked recursively, collecting every directory
+// that go/build recognises as a package, skipping vendor/testdata and
+// anything matching --skip.
+func expandPackages(pathArg string) ([]string, error) {
+	if pathArg != "..." && !strings.HasSuffix(pathArg, "/...") {
+		return []string{pathArg}, nil
+	}
+
+	root := strings.TrimSuffix(strings.TrimSuffix(pathArg, "..."), "/")
+	if root == "" {
+		root = "."
+	}
+
+	skip := map[string]bool{}
+	for _, dir := range defaultSkipDirs {
+		skip[dir] = true
+	}
+	for _, dir := range *skipFlag {
+		skip[dir] = true
+	}
+
+	var dirs []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		base := filepath.Base(path)
+		if base != "." && strings.HasPrefix(base, ".") {
+			return filepath.SkipDir
+		}
+		if skip[base] {
+			return filepath.SkipDir
+		}
+		if _, err := build.ImportDir(path, 0); err != nil {
+			if _, ok := err.(*build.NoGoError); ok {
+				return nil
+			}
+			debug("skipping %s: %s", path, err)
+			return nil
+		}
+		dirs = append(dirs, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dirs, nil
+}
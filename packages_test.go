@@ -0,0 +1,121 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// buildPackageTree lays out a root with: a normal package, a vendored
+// package, a testdata fixture, a dot-prefixed directory, and a directory
+// meant to be excluded via --skip, and returns the root.
+func buildPackageTree(t *testing.T) string {
+	t.Helper()
+	root, err := ioutil.TempDir("", "gometalinter-packages-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(root) })
+
+	pkgs := map[string]string{
+		filepath.Join(root, "pkg", "a.go"):                 "package a\n",
+		filepath.Join(root, "vendor", "dep", "dep.go"):     "package dep\n",
+		filepath.Join(root, "testdata", "fixture", "f.go"): "package fixture\n",
+		filepath.Join(root, ".git", "hooks", "hidden.go"):  "package hidden\n",
+		filepath.Join(root, "skipme", "skip.go"):           "package skipme\n",
+		filepath.Join(root, "sub", "leaf", "leaf.go"):      "package leaf\n",
+	}
+	for path, src := range pkgs {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestExpandPackagesPlainPath(t *testing.T) {
+	dirs, err := expandPackages("./some/path")
+	if err != nil {
+		t.Fatalf("expandPackages: %v", err)
+	}
+	if len(dirs) != 1 || dirs[0] != "./some/path" {
+		t.Errorf("expandPackages(plain path) = %v, want it returned as-is without walking", dirs)
+	}
+}
+
+func TestExpandPackagesRecurseDefaultSkips(t *testing.T) {
+	root := buildPackageTree(t)
+
+	dirs, err := expandPackages(root + "/...")
+	if err != nil {
+		t.Fatalf("expandPackages: %v", err)
+	}
+	sort.Strings(dirs)
+
+	want := []string{
+		filepath.Join(root, "pkg"),
+		filepath.Join(root, "skipme"),
+		filepath.Join(root, "sub", "leaf"),
+	}
+	sort.Strings(want)
+
+	if len(dirs) != len(want) {
+		t.Fatalf("expandPackages(%s/...) = %v, want %v", root, dirs, want)
+	}
+	for i := range want {
+		if dirs[i] != want[i] {
+			t.Errorf("expandPackages(%s/...) = %v, want %v", root, dirs, want)
+			break
+		}
+	}
+}
+
+func TestExpandPackagesCustomSkip(t *testing.T) {
+	root := buildPackageTree(t)
+
+	saved := *skipFlag
+	*skipFlag = []string{"skipme"}
+	t.Cleanup(func() { *skipFlag = saved })
+
+	dirs, err := expandPackages(root + "/...")
+	if err != nil {
+		t.Fatalf("expandPackages: %v", err)
+	}
+	for _, dir := range dirs {
+		if filepath.Base(dir) == "skipme" {
+			t.Errorf("expandPackages with --skip skipme still returned %s", dir)
+		}
+	}
+}
+
+func TestExpandPackagesBareEllipsis(t *testing.T) {
+	root := buildPackageTree(t)
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	dirs, err := expandPackages("...")
+	if err != nil {
+		t.Fatalf("expandPackages: %v", err)
+	}
+	found := false
+	for _, dir := range dirs {
+		if filepath.Clean(dir) == filepath.Join(".", "pkg") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expandPackages(\"...\") = %v, want it to include ./pkg relative to the cwd", dirs)
+	}
+}
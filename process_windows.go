@@ -0,0 +1,18 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// setProcessGroup is a no-op on Windows; killProcessGroup uses taskkill's
+// /T flag to walk the process tree instead of relying on a process group.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup forcibly kills cmd and its descendants via taskkill.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+}